@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"database/sql"
 	"encoding/json"
+	"io"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -15,17 +18,27 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go.opentelemetry.io/otel/trace"
 
+	"orderflow/pkg/auth"
+	"orderflow/pkg/idempotency"
 	"orderflow/pkg/logger"
 	"orderflow/pkg/order"
+	evkafka "orderflow/pkg/order/events/kafka"
+	evmemory "orderflow/pkg/order/events/memory"
+	evnats "orderflow/pkg/order/events/nats"
+	pop "orderflow/pkg/order/pop"
 	pg "orderflow/pkg/order/postgres"
 	"orderflow/pkg/otel"
 )
 
 var (
-	redisClient *redis.Client
-	repo        order.Repository
-	log         *logger.Logger
-	tracer      trace.Tracer
+	redisClient      *redis.Client
+	repo             order.Repository
+	log              *logger.Logger
+	tracer           trace.Tracer
+	issuer           *auth.Issuer
+	revoker          *auth.RedisRevoker
+	jwtKeyFunc       auth.KeyFunc
+	idempotencyStore *idempotency.Store
 )
 
 // @title OrderFlow API
@@ -43,30 +56,68 @@ func main() {
 	defer shutdown(context.Background())
 	tracer = tp.Tracer("orderflow")
 
-	db, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
-	if err != nil {
-		log.Error(context.Background(), "db connect", "error", err)
-		os.Exit(1)
+	// DB_DRIVER selects the Repository backend. "pop" delegates dialect
+	// selection (postgres/mysql/cockroach/sqlite3) to gobuffalo/pop based on
+	// the DATABASE_URL scheme; anything else keeps the direct lib/pq path.
+	if os.Getenv("DB_DRIVER") == "pop" {
+		popRepo, err := pop.New(envOr("POP_CONNECTION", "production"))
+		if err != nil {
+			log.Error(context.Background(), "pop connect", "error", err)
+			os.Exit(1)
+		}
+		repo = popRepo
+	} else {
+		db, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
+		if err != nil {
+			log.Error(context.Background(), "db connect", "error", err)
+			os.Exit(1)
+		}
+		if _, err := db.Exec("CREATE TABLE IF NOT EXISTS orders (id TEXT PRIMARY KEY, item TEXT, quantity INT, version INT NOT NULL DEFAULT 1)"); err != nil {
+			log.Error(context.Background(), "create table", "error", err)
+			os.Exit(1)
+		}
+		if _, err := db.Exec("CREATE TABLE IF NOT EXISTS outbox (id SERIAL PRIMARY KEY, event_type TEXT, payload TEXT, published BOOLEAN DEFAULT false)"); err != nil {
+			log.Error(context.Background(), "create outbox table", "error", err)
+			os.Exit(1)
+		}
+		repo = pg.New(db)
 	}
-	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS orders (id TEXT PRIMARY KEY, item TEXT, quantity INT)"); err != nil {
-		log.Error(context.Background(), "create table", "error", err)
-		os.Exit(1)
+
+	// EVENTS_BACKEND selects where order.created/updated/deleted events go.
+	// The postgres repo already wrote a transactional outbox row for every
+	// mutation above, so it's drained by a relay instead of decorating repo;
+	// every other backend is wrapped so it publishes directly.
+	publisher := newEventPublisher()
+	if outboxSource, ok := repo.(order.OutboxSource); ok {
+		relay := order.NewOutboxRelay(outboxSource, publisher)
+		go relay.Run(context.Background(), 2*time.Second)
+	} else {
+		repo = order.NewPublishingRepository(repo, publisher)
 	}
-	repo = pg.New(db)
 
 	redisClient = redis.NewClient(&redis.Options{Addr: os.Getenv("REDIS_ADDR")})
+	idempotencyStore = idempotency.NewStore(redisClient, idempotency.DefaultTTL)
+
+	// JWT_KID/JWT_SECRET configure the active signing key; jwtKeyFunc only
+	// accepts that kid today but gives a place to add prior keys when
+	// rotating without invalidating tokens already in flight.
+	kid := envOr("JWT_KID", "v1")
+	secret := []byte(os.Getenv("JWT_SECRET"))
+	issuer = auth.NewIssuer(kid, secret, time.Hour)
+	jwtKeyFunc = auth.StaticKeyFunc(kid, secret)
+	revoker = auth.NewRedisRevoker(redisClient)
 
 	r := mux.NewRouter()
 	r.Use(traceMiddleware)
 	r.HandleFunc("/login", loginHandler).Methods(http.MethodPost)
+	r.Handle("/logout", auth.Middleware(jwtKeyFunc, revoker, "")(http.HandlerFunc(logoutHandler))).Methods(http.MethodPost)
 
 	api := r.PathPrefix("/orders").Subrouter()
-	api.Use(authMiddleware)
-	api.HandleFunc("", createOrderHandler).Methods(http.MethodPost)
-	api.HandleFunc("", listOrdersHandler).Methods(http.MethodGet)
-	api.HandleFunc("/{id}", getOrderHandler).Methods(http.MethodGet)
-	api.HandleFunc("/{id}", updateOrderHandler).Methods(http.MethodPut)
-	api.HandleFunc("/{id}", deleteOrderHandler).Methods(http.MethodDelete)
+	api.Handle("", auth.Middleware(jwtKeyFunc, revoker, "writer")(http.HandlerFunc(createOrderHandler))).Methods(http.MethodPost)
+	api.Handle("", auth.Middleware(jwtKeyFunc, revoker, "reader")(http.HandlerFunc(listOrdersHandler))).Methods(http.MethodGet)
+	api.Handle("/{id}", auth.Middleware(jwtKeyFunc, revoker, "reader")(http.HandlerFunc(getOrderHandler))).Methods(http.MethodGet)
+	api.Handle("/{id}", auth.Middleware(jwtKeyFunc, revoker, "writer")(http.HandlerFunc(updateOrderHandler))).Methods(http.MethodPut)
+	api.Handle("/{id}", auth.Middleware(jwtKeyFunc, revoker, "admin")(http.HandlerFunc(deleteOrderHandler))).Methods(http.MethodDelete)
 
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
@@ -76,13 +127,14 @@ func main() {
 	}
 }
 
-// loginHandler handles user login and session creation.
+// loginHandler authenticates the user and mints a signed JWT carrying their
+// roles.
 // @Summary Login
-// @Description Authenticates user and sets session cookie
+// @Description Authenticates user and returns a bearer token
 // @Accept json
 // @Produce json
 // @Param creds body loginRequest true "Credentials"
-// @Success 200
+// @Success 200 {object} loginResponse
 // @Router /login [post]
 func loginHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, span := otel.AddSpan(r.Context(), "loginHandler")
@@ -93,47 +145,131 @@ func loginHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid credentials", http.StatusBadRequest)
 		return
 	}
-	sid := strconv.FormatInt(time.Now().UnixNano(), 10)
-	if err := redisClient.Set(ctx, "session:"+sid, req.Username, time.Hour).Err(); err != nil {
-		http.Error(w, "session error", http.StatusInternalServerError)
+	if !checkPassword(req.Password) {
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
 		return
 	}
-	http.SetCookie(w, &http.Cookie{Name: "session_id", Value: sid, Path: "/", Expires: time.Now().Add(time.Hour), HttpOnly: true})
-	w.WriteHeader(http.StatusOK)
+	token, err := issuer.Issue(req.Username, rolesFor(req.Username))
+	if err != nil {
+		log.Error(ctx, "issue token", "error", err)
+		http.Error(w, "token error", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(loginResponse{Token: token})
 }
 
-// authMiddleware ensures a valid session exists.
-func authMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		c, err := r.Cookie("session_id")
-		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
-		user, err := redisClient.Get(r.Context(), "session:"+c.Value).Result()
-		if err != nil || user == "" {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
-		ctx := context.WithValue(r.Context(), "user", user)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// rolesFor is a placeholder role lookup; a real deployment would consult a
+// user store instead of deriving roles from the username.
+func rolesFor(username string) []string {
+	if username == "admin" {
+		return []string{"reader", "writer", "admin"}
+	}
+	return []string{"reader", "writer"}
+}
+
+// checkPassword is a placeholder credential check, just like rolesFor is a
+// placeholder role lookup; a real deployment would verify password against
+// a per-user credential store instead of a single shared LOGIN_PASSWORD.
+// It exists so loginHandler has some gate before minting a token, rather
+// than issuing one (including the admin role) for any username with no
+// password check at all.
+func checkPassword(password string) bool {
+	want := os.Getenv("LOGIN_PASSWORD")
+	if want == "" || password == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(password), []byte(want)) == 1
+}
+
+// logoutHandler revokes the bearer token presented in the request so it is
+// rejected by auth.Middleware even before it expires.
+// @Summary Logout
+// @Description Revokes the caller's bearer token
+// @Success 200
+// @Security ApiKeyAuth
+// @Router /logout [post]
+func logoutHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.AddSpan(r.Context(), "logoutHandler")
+	defer span.End()
+
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	ttl := time.Until(principal.Expiry)
+	if ttl < 0 {
+		ttl = 0
+	}
+	if err := revoker.Revoke(ctx, principal.JTI, ttl); err != nil {
+		log.Error(ctx, "revoke token", "error", err)
+		http.Error(w, "logout failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
 }
 
-// createOrderHandler creates a new order.
+// createOrderHandler creates a new order. A client that sets the
+// Idempotency-Key header gets the original response replayed on retry
+// instead of a duplicate order, as long as the request body is unchanged.
+// A retry that arrives while the first request for the same key is still
+// being handled gets 409 rather than racing it, since only one of them can
+// win the underlying Claim.
 // @Summary Create order
 // @Accept json
 // @Produce json
 // @Param order body order.Order true "Order"
+// @Param Idempotency-Key header string false "Replay key for safe retries"
 // @Success 201 {object} order.Order
+// @Failure 409 {string} string "request with this idempotency key is already in progress"
 // @Security ApiKeyAuth
 // @Router /orders [post]
 func createOrderHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, span := otel.AddSpan(r.Context(), "createOrderHandler")
 	defer span.End()
 
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var idemKey string
+	idemSaved := false
+	if raw := r.Header.Get("Idempotency-Key"); raw != "" {
+		idemKey = idempotency.Key(raw, body)
+		claimed, err := idempotencyStore.Claim(ctx, idemKey)
+		if err != nil {
+			log.Error(ctx, "idempotency claim", "error", err)
+		} else if !claimed {
+			cached, ok, err := idempotencyStore.Load(ctx, idemKey)
+			if err != nil {
+				log.Error(ctx, "idempotency lookup", "error", err)
+			}
+			if ok {
+				w.WriteHeader(cached.StatusCode)
+				w.Write(cached.Body)
+				return
+			}
+			http.Error(w, "request with this idempotency key is already in progress", http.StatusConflict)
+			return
+		} else {
+			// Claimed but not yet Saved: release on every exit from here on
+			// unless Save succeeds below, so a failure partway through this
+			// request doesn't leave the key stuck 409-ing legitimate
+			// retries for the rest of idempotency.DefaultTTL.
+			defer func() {
+				if !idemSaved {
+					if err := idempotencyStore.Release(ctx, idemKey); err != nil {
+						log.Error(ctx, "idempotency release", "error", err)
+					}
+				}
+			}()
+		}
+	}
+
 	var o order.Order
-	if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
+	if err := json.Unmarshal(body, &o); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -145,28 +281,65 @@ func createOrderHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	o.Version = 1
+
+	respBody, err := json.Marshal(o)
+	if err != nil {
+		log.Error(ctx, "marshal order", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if idemKey != "" {
+		if err := idempotencyStore.Save(ctx, idemKey, idempotency.Response{StatusCode: http.StatusCreated, Body: respBody}); err != nil {
+			log.Error(ctx, "idempotency save", "error", err)
+		} else {
+			idemSaved = true
+		}
+	}
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(o)
+	w.Write(respBody)
 }
 
-// listOrdersHandler lists orders.
+// listOrdersHandler lists orders, paginated with a keyset cursor.
 // @Summary List orders
 // @Produce json
-// @Success 200 {array} order.Order
+// @Param limit query int false "Max items per page"
+// @Param cursor query string false "Opaque cursor from a previous page"
+// @Param item query string false "Filter by exact item name"
+// @Param min_quantity query int false "Minimum quantity (inclusive)"
+// @Param max_quantity query int false "Maximum quantity (inclusive)"
+// @Param sort query string false "Sort by id, item, or quantity"
+// @Success 200 {object} order.ListResult
 // @Security ApiKeyAuth
 // @Router /orders [get]
 func listOrdersHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, span := otel.AddSpan(r.Context(), "listOrdersHandler")
 	defer span.End()
 
-	orders, err := repo.List(ctx)
+	query := r.URL.Query()
+	opts := order.ListOptions{
+		Cursor:     query.Get("cursor"),
+		ItemFilter: query.Get("item"),
+		SortBy:     order.SortBy(query.Get("sort")),
+	}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil {
+		opts.Limit = limit
+	}
+	if min, err := strconv.Atoi(query.Get("min_quantity")); err == nil {
+		opts.MinQuantity = &min
+	}
+	if max, err := strconv.Atoi(query.Get("max_quantity")); err == nil {
+		opts.MaxQuantity = &max
+	}
+
+	result, err := repo.List(ctx, opts)
 	if err != nil {
 		log.Error(ctx, "list orders", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(orders)
+	json.NewEncoder(w).Encode(result)
 }
 
 // getOrderHandler retrieves an order by ID.
@@ -194,13 +367,16 @@ func getOrderHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(o)
 }
 
-// updateOrderHandler updates an existing order.
+// updateOrderHandler updates an existing order. The request body must carry
+// the Version last read from the order; a stale Version is rejected with
+// 409 rather than silently overwriting a concurrent update.
 // @Summary Update order
 // @Accept json
 // @Produce json
 // @Param id path string true "Order ID"
 // @Param order body order.Order true "Order"
 // @Success 200 {object} order.Order
+// @Failure 409 {string} string "version conflict"
 // @Security ApiKeyAuth
 // @Router /orders/{id} [put]
 func updateOrderHandler(w http.ResponseWriter, r *http.Request) {
@@ -215,14 +391,19 @@ func updateOrderHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	o.ID = id
 	if err := repo.Update(ctx, o); err != nil {
-		if err == order.ErrNotFound {
+		switch err {
+		case order.ErrNotFound:
 			http.NotFound(w, r)
 			return
+		case order.ErrConflict:
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
 		}
 		log.Error(ctx, "update order", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	o.Version++
 	json.NewEncoder(w).Encode(o)
 }
 
@@ -256,8 +437,50 @@ func traceMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// envOr returns the named environment variable, or fallback if it is unset.
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// newEventPublisher selects an order.EventPublisher based on EVENTS_BACKEND
+// ("kafka", "nats", or the default in-memory publisher for local/dev use).
+func newEventPublisher() order.EventPublisher {
+	switch os.Getenv("EVENTS_BACKEND") {
+	case "kafka":
+		pub, err := evkafka.New(evkafka.Config{
+			Brokers: strings.Split(os.Getenv("KAFKA_BROKERS"), ","),
+			Topic:   envOr("KAFKA_TOPIC", "orders"),
+		})
+		if err != nil {
+			log.Error(context.Background(), "kafka publisher", "error", err)
+			os.Exit(1)
+		}
+		return pub
+	case "nats":
+		pub, err := evnats.New(evnats.Config{
+			URL:     envOr("NATS_URL", "nats://127.0.0.1:4222"),
+			Subject: envOr("NATS_SUBJECT", "orders"),
+		})
+		if err != nil {
+			log.Error(context.Background(), "nats publisher", "error", err)
+			os.Exit(1)
+		}
+		return pub
+	default:
+		return evmemory.New()
+	}
+}
+
 // loginRequest represents login credentials.
 type loginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 }
+
+// loginResponse carries the bearer token minted for a successful login.
+type loginResponse struct {
+	Token string `json:"token"`
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeRevoker is an in-memory Revoker for tests.
+type fakeRevoker struct {
+	revoked map[string]bool
+}
+
+func newFakeRevoker() *fakeRevoker { return &fakeRevoker{revoked: map[string]bool{}} }
+
+func (f *fakeRevoker) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	f.revoked[jti] = true
+	return nil
+}
+
+func (f *fakeRevoker) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return f.revoked[jti], nil
+}
+
+// serve authenticates a request through mw and calls final, the handler mw
+// wraps, returning the response plus the Principal Middleware injected into
+// final's context. The context must be captured by a handler mw itself
+// calls into, not by a wrapper around mw's own result, or it would read the
+// request's pre-injection context instead of what final actually sees.
+func serve(t *testing.T, mw func(http.Handler) http.Handler, final http.HandlerFunc, token string) (*httptest.ResponseRecorder, Principal) {
+	t.Helper()
+	var got Principal
+	wrapped := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+		final(w, r)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	wrapped.ServeHTTP(rec, req)
+	return rec, got
+}
+
+func TestMiddlewareAllowsValidTokenWithRole(t *testing.T) {
+	issuer := NewIssuer("v1", []byte("secret"), time.Hour)
+	token, err := issuer.Issue("alice", []string{"reader"})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	mw := Middleware(StaticKeyFunc("v1", []byte("secret")), newFakeRevoker(), "reader")
+
+	rec, p := serve(t, mw, func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }, token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if p.Subject != "alice" || !p.HasRole("reader") {
+		t.Fatalf("unexpected principal: %+v", p)
+	}
+}
+
+func TestMiddlewareRejectsMissingRole(t *testing.T) {
+	issuer := NewIssuer("v1", []byte("secret"), time.Hour)
+	token, err := issuer.Issue("bob", []string{"reader"})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	mw := Middleware(StaticKeyFunc("v1", []byte("secret")), newFakeRevoker(), "admin")
+
+	rec, _ := serve(t, mw, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler should not run without the required role")
+	}, token)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestMiddlewareRejectsRevokedToken(t *testing.T) {
+	issuer := NewIssuer("v1", []byte("secret"), time.Hour)
+	token, err := issuer.Issue("carol", []string{"reader"})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	keyFunc := StaticKeyFunc("v1", []byte("secret"))
+
+	// Authenticate once to learn the jti Issue generated, then revoke it.
+	revoker := newFakeRevoker()
+	passthroughMw := Middleware(keyFunc, revoker, "")
+	_, p := serve(t, passthroughMw, func(w http.ResponseWriter, r *http.Request) {}, token)
+	if err := revoker.Revoke(context.Background(), p.JTI, time.Hour); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	mw := Middleware(keyFunc, revoker, "reader")
+	rec, _ := serve(t, mw, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler should not run for a revoked token")
+	}, token)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
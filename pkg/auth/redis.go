@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisRevoker implements Revoker as a jti blacklist in Redis, so a logged
+// out token is rejected until it would have expired anyway.
+type RedisRevoker struct {
+	client *redis.Client
+}
+
+// NewRedisRevoker returns a Revoker backed by client.
+func NewRedisRevoker(client *redis.Client) *RedisRevoker {
+	return &RedisRevoker{client: client}
+}
+
+// Revoke blacklists jti for ttl, which should be the token's remaining
+// lifetime so the blacklist entry does not outlive the token itself.
+func (r *RedisRevoker) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	return r.client.Set(ctx, "jwt:revoked:"+jti, "1", ttl).Err()
+}
+
+// IsRevoked reports whether jti has been blacklisted.
+func (r *RedisRevoker) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := r.client.Exists(ctx, "jwt:revoked:"+jti).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
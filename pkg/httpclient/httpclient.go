@@ -0,0 +1,173 @@
+// Package httpclient gives every outgoing HTTP call the same tracing and
+// structured logging handlers otherwise wire up by hand: a child span from
+// the caller's context, an injected W3C traceparent header, and a redacted
+// log line keyed to the same trace id as the rest of the request.
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"orderflow/pkg/logger"
+	"orderflow/pkg/otel"
+)
+
+// Options configures New.
+type Options struct {
+	// Tracer starts the child span for each outgoing request. Pass the
+	// tracer created in main.go (the one traceMiddleware injects into every
+	// incoming request's context).
+	Tracer trace.Tracer
+	// Logger records one line per request. Nil disables logging.
+	Logger *logger.Logger
+	// Transport is the RoundTripper to wrap; defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+	// RedactHeaders and RedactFields name request headers and request/response
+	// JSON body fields (case-insensitive) whose values are replaced with
+	// "[REDACTED]" before being logged; response headers are not logged. The
+	// request and response bodies actually sent/received are never modified.
+	RedactHeaders []string
+	RedactFields  []string
+}
+
+// New returns an *http.Client instrumented per opts.
+func New(opts Options) *http.Client {
+	base := opts.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &http.Client{
+		Transport: &tracingTransport{
+			base:          base,
+			tracer:        opts.Tracer,
+			logger:        opts.Logger,
+			redactHeaders: toSet(opts.RedactHeaders),
+			redactFields:  toSet(opts.RedactFields),
+		},
+	}
+}
+
+type tracingTransport struct {
+	base          http.RoundTripper
+	tracer        trace.Tracer
+	logger        *logger.Logger
+	redactHeaders map[string]bool
+	redactFields  map[string]bool
+}
+
+// RoundTrip starts a child span, injects its traceparent into req, performs
+// the call, and logs the outcome.
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	var span trace.Span
+	if t.tracer != nil {
+		ctx, span = t.tracer.Start(ctx, "http "+req.Method+" "+req.URL.Host)
+		defer span.End()
+	}
+	req = req.WithContext(ctx)
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	var reqBody []byte
+	if t.logger != nil && req.Body != nil {
+		redacted, body, err := peekAndRedactBody(req.Body, t.redactFields)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+		reqBody = redacted
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	duration := time.Since(start)
+
+	status := 0
+	var respSize int64 = -1
+	var respBody []byte
+	if resp != nil {
+		status = resp.StatusCode
+		respSize = resp.ContentLength
+		if t.logger != nil && resp.Body != nil {
+			if redacted, body, berr := peekAndRedactBody(resp.Body, t.redactFields); berr == nil {
+				resp.Body = body
+				respBody = redacted
+			}
+		}
+	}
+
+	if span != nil {
+		span.SetAttributes(
+			attribute.String("http.method", req.Method),
+			attribute.String("http.url", redactURL(req.URL)),
+			attribute.Int64("http.request_content_length", req.ContentLength),
+			attribute.Int("http.status_code", status),
+			attribute.Int64("http.response_content_length", respSize),
+		)
+		if err != nil {
+			span.RecordError(err)
+		}
+	}
+
+	if t.logger != nil {
+		fields := []interface{}{
+			"method", req.Method,
+			"url", redactURL(req.URL),
+			"headers", redactHeaderValues(req.Header, t.redactHeaders),
+			"status", status,
+			"duration_ms", duration.Milliseconds(),
+			"trace_id", otel.GetTraceID(ctx),
+		}
+		if len(reqBody) > 0 {
+			fields = append(fields, "request_body", string(reqBody))
+		}
+		if len(respBody) > 0 {
+			fields = append(fields, "response_body", string(respBody))
+		}
+		if err != nil {
+			t.logger.Error(ctx, "outgoing http request failed", append(fields, "error", err)...)
+		} else {
+			t.logger.Info(ctx, "outgoing http request", fields...)
+		}
+	}
+
+	return resp, err
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[normalize(v)] = true
+	}
+	return set
+}
+
+func normalize(s string) string {
+	out := make([]byte, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		out[i] = c
+	}
+	return string(out)
+}
+
+// redactURL strips a userinfo component (user:pass@host) from u before it is
+// logged or attached to a span, since that's a common place for a password
+// to leak even when it's not a named header/body field.
+func redactURL(u *url.URL) string {
+	if u.User == nil {
+		return u.String()
+	}
+	redacted := *u
+	redacted.User = url.UserPassword(u.User.Username(), redactedPlaceholder)
+	return redacted.String()
+}
@@ -0,0 +1,79 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+const redactedPlaceholder = "[REDACTED]"
+
+// redactHeaderValues returns a copy of h with any header named in redact
+// (case-insensitive) replaced by redactedPlaceholder.
+func redactHeaderValues(h http.Header, redact map[string]bool) http.Header {
+	if len(redact) == 0 {
+		return h
+	}
+	out := h.Clone()
+	for name := range out {
+		if redact[normalize(name)] {
+			out[name] = []string{redactedPlaceholder}
+		}
+	}
+	return out
+}
+
+// peekAndRedactBody reads up to all of body, replacing JSON fields named in
+// redact (case-insensitive, at any nesting depth) with redactedPlaceholder,
+// and returns the redacted copy alongside a fresh reader the caller must use
+// to replace the original body so the request/response is unaffected.
+//
+// Non-JSON or unparsable bodies are returned unredacted, since we cannot
+// tell where a secret might be hiding in an opaque payload without risking
+// corrupting it.
+func peekAndRedactBody(body io.ReadCloser, redact map[string]bool) (redacted []byte, replacement io.ReadCloser, err error) {
+	if body == nil {
+		return nil, nil, nil
+	}
+	raw, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return nil, io.NopCloser(bytes.NewReader(nil)), err
+	}
+	replacement = io.NopCloser(bytes.NewReader(raw))
+
+	if len(redact) == 0 || len(raw) == 0 {
+		return raw, replacement, nil
+	}
+
+	var parsed interface{}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return raw, replacement, nil
+	}
+	redactJSON(parsed, redact)
+	out, err := json.Marshal(parsed)
+	if err != nil {
+		return raw, replacement, nil
+	}
+	return out, replacement, nil
+}
+
+// redactJSON walks a decoded JSON value in place, blanking any object field
+// whose key matches redact (case-insensitive).
+func redactJSON(v interface{}, redact map[string]bool) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, fieldVal := range val {
+			if redact[normalize(k)] {
+				val[k] = redactedPlaceholder
+				continue
+			}
+			redactJSON(fieldVal, redact)
+		}
+	case []interface{}:
+		for _, item := range val {
+			redactJSON(item, redact)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package idempotency lets handlers replay the original response for a
+// repeated request instead of re-executing it, keyed off a client-supplied
+// Idempotency-Key header.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultTTL is how long a stored response is replayed before the key is
+// forgotten and a repeat request is treated as new.
+const DefaultTTL = 24 * time.Hour
+
+// inProgressMarker is written by Claim in place of a Response while the
+// original request for a key is still being handled, so a concurrent retry's
+// Load can tell "still working" apart from "key never seen".
+const inProgressMarker = "in-progress"
+
+// Response is the HTTP response captured for a request, so a replay can
+// reproduce it byte-for-byte.
+type Response struct {
+	StatusCode int    `json:"status_code"`
+	Body       []byte `json:"body"`
+}
+
+// Store persists Responses in Redis, keyed by Key.
+type Store struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewStore returns a Store backed by client, replaying saved responses for
+// ttl before expiring them.
+func NewStore(client *redis.Client, ttl time.Duration) *Store {
+	return &Store{client: client, ttl: ttl}
+}
+
+// Key combines a client-supplied idempotency key with a hash of the request
+// body, so replaying the same key with a different body is treated as a new
+// request rather than silently returning a stale response.
+func Key(idempotencyKey string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return "idempotency:" + idempotencyKey + ":" + hex.EncodeToString(sum[:])
+}
+
+// Claim atomically reserves key via SETNX, so of several concurrent
+// requests sharing an Idempotency-Key only one gets claimed == true and
+// goes on to do the work; the rest must Load instead of executing it again.
+// This is what makes the key check-and-act free: check-then-act (Load, then
+// act only if absent) leaves a window where two concurrent retries both see
+// no stored response and both execute.
+func (s *Store) Claim(ctx context.Context, key string) (claimed bool, err error) {
+	ok, err := s.client.SetNX(ctx, key, inProgressMarker, s.ttl).Result()
+	if err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+// Release discards a Claim that never reached Save, e.g. because the
+// handler failed partway through, so the key doesn't sit holding
+// inProgressMarker for the full TTL and permanently 409 every legitimate
+// retry until it expires.
+func (s *Store) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
+
+// Load returns the Response previously saved under key. It reports ok ==
+// false both when key is unseen and when it is still claimed but not yet
+// Saved, since either way the caller has no completed response to replay.
+func (s *Store) Load(ctx context.Context, key string) (Response, bool, error) {
+	raw, err := s.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return Response{}, false, nil
+	}
+	if err != nil {
+		return Response{}, false, err
+	}
+	if string(raw) == inProgressMarker {
+		return Response{}, false, nil
+	}
+	var resp Response
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return Response{}, false, err
+	}
+	return resp, true, nil
+}
+
+// Save overwrites key's Claim marker with resp, so later Loads can replay
+// it until the Store's TTL elapses.
+func (s *Store) Save(ctx context.Context, key string, resp Response) error {
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, key, raw, s.ttl).Err()
+}
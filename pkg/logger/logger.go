@@ -1,16 +1,83 @@
-// Package logger provides a zap-based application logger.
+// Package logger provides the structured logger every handler and outgoing
+// HTTP call writes through, backed by zap and tagged with whatever trace id
+// is active on the request's context.
 package logger
 
-import "go.uber.org/zap"
+import (
+	"context"
+	"io"
 
-// Log is the global zap logger used across the project.
-var Log *zap.Logger
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
 
-// Init configures the global logger in production mode.
-func Init() {
-	var err error
-	Log, err = zap.NewProduction()
-	if err != nil {
-		panic(err)
+// Level selects the minimum severity a Logger writes.
+type Level int
+
+// Levels New accepts, lowest to highest severity.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) zapLevel() zapcore.Level {
+	switch l {
+	case LevelDebug:
+		return zapcore.DebugLevel
+	case LevelWarn:
+		return zapcore.WarnLevel
+	case LevelError:
+		return zapcore.ErrorLevel
+	default:
+		return zapcore.InfoLevel
 	}
 }
+
+// Logger writes structured JSON log lines to an underlying zap.Logger, each
+// tagged with the configured service name and, when traceIDFn finds one on
+// the call's context, the active trace id.
+type Logger struct {
+	zap       *zap.Logger
+	service   string
+	traceIDFn func(context.Context) string
+}
+
+// New returns a Logger writing JSON lines at minLevel or above to w, each
+// tagged with service and the trace id traceIDFn extracts from the call's
+// context (e.g. otel.GetTraceID), when one is active.
+func New(w io.Writer, minLevel Level, service string, traceIDFn func(context.Context) string) *Logger {
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(w), minLevel.zapLevel())
+	return &Logger{zap: zap.New(core), service: service, traceIDFn: traceIDFn}
+}
+
+// log writes msg at level, with kv as alternating key/value pairs.
+func (l *Logger) log(level zapcore.Level, ctx context.Context, msg string, kv ...interface{}) {
+	ce := l.zap.Check(level, msg)
+	if ce == nil {
+		return
+	}
+	fields := make([]zap.Field, 0, len(kv)/2+2)
+	fields = append(fields, zap.String("service", l.service))
+	if l.traceIDFn != nil {
+		if id := l.traceIDFn(ctx); id != "" {
+			fields = append(fields, zap.String("trace_id", id))
+		}
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, _ := kv[i].(string)
+		fields = append(fields, zap.Any(key, kv[i+1]))
+	}
+	ce.Write(fields...)
+}
+
+// Info logs msg at info level.
+func (l *Logger) Info(ctx context.Context, msg string, kv ...interface{}) {
+	l.log(zapcore.InfoLevel, ctx, msg, kv...)
+}
+
+// Error logs msg at error level.
+func (l *Logger) Error(ctx context.Context, msg string, kv ...interface{}) {
+	l.log(zapcore.ErrorLevel, ctx, msg, kv...)
+}
@@ -0,0 +1,335 @@
+// Package conformance provides a shared test suite that every order.Repository
+// implementation must satisfy, so that memory, postgres, and pop backed repos
+// all agree on ErrNotFound semantics, optimistic-concurrency conflicts, and
+// list ordering.
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"orderflow/pkg/order"
+)
+
+// Factory builds a fresh, empty Repository for a single test run and returns
+// a cleanup function that releases any resources it allocated.
+type Factory func(t *testing.T) (repo order.Repository, cleanup func())
+
+// Run exercises the full Repository contract against the repository produced
+// by factory. Call this from each backend's own test file, e.g.:
+//
+//	func TestRepository(t *testing.T) {
+//		conformance.Run(t, func(t *testing.T) (order.Repository, func()) {
+//			return New(), func() {}
+//		})
+//	}
+func Run(t *testing.T, factory Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) { testCreateAndGet(t, factory) })
+	t.Run("GetMissingReturnsErrNotFound", func(t *testing.T) { testGetMissing(t, factory) })
+	t.Run("UpdateBumpsVersion", func(t *testing.T) { testUpdateBumpsVersion(t, factory) })
+	t.Run("UpdateStaleVersionReturnsErrConflict", func(t *testing.T) { testUpdateConflict(t, factory) })
+	t.Run("UpdateMissingReturnsErrNotFound", func(t *testing.T) { testUpdateMissing(t, factory) })
+	t.Run("DeleteMissingReturnsErrNotFound", func(t *testing.T) { testDeleteMissing(t, factory) })
+	t.Run("ListIsOrderedByID", func(t *testing.T) { testListOrdered(t, factory) })
+	t.Run("ListPaginatesWithCursor", func(t *testing.T) { testListPaginates(t, factory) })
+	t.Run("ListPaginatesWithNonDefaultSort", func(t *testing.T) { testListPaginatesNonDefaultSort(t, factory) })
+	t.Run("ListStableUnderConcurrentInserts", func(t *testing.T) { testListStableUnderConcurrentInserts(t, factory) })
+}
+
+func testCreateAndGet(t *testing.T, factory Factory) {
+	repo, cleanup := factory(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	o := order.Order{ID: "1", Item: "Widget", Quantity: 2}
+	if err := repo.Create(ctx, o); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	o.Version = 1 // Create always starts a new order at Version 1.
+	got, err := repo.Get(ctx, o.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got != o {
+		t.Fatalf("got %+v, want %+v", got, o)
+	}
+}
+
+func testGetMissing(t *testing.T, factory Factory) {
+	repo, cleanup := factory(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if _, err := repo.Get(ctx, "missing"); err != order.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// testUpdateBumpsVersion checks that a well-formed Update (carrying the
+// order's current Version) succeeds and increments the stored Version, so a
+// second Update with the same (now stale) Version is rejected.
+func testUpdateBumpsVersion(t *testing.T, factory Factory) {
+	repo, cleanup := factory(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	o := order.Order{ID: "1", Item: "Widget", Quantity: 2, Version: 1}
+	if err := repo.Create(ctx, o); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	o.Quantity = 5
+	if err := repo.Update(ctx, o); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got, err := repo.Get(ctx, o.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Quantity != 5 {
+		t.Fatalf("expected quantity 5, got %d", got.Quantity)
+	}
+	if got.Version != 2 {
+		t.Fatalf("expected version 2 after one update, got %d", got.Version)
+	}
+
+	if err := repo.Update(ctx, o); err != order.ErrConflict {
+		t.Fatalf("expected ErrConflict replaying a stale version, got %v", err)
+	}
+}
+
+// testUpdateConflict checks that an Update naming a Version older than the
+// latest stored one fails with ErrConflict rather than overwriting it.
+func testUpdateConflict(t *testing.T, factory Factory) {
+	repo, cleanup := factory(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	o := order.Order{ID: "1", Item: "Widget", Quantity: 2, Version: 1}
+	if err := repo.Create(ctx, o); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := repo.Update(ctx, order.Order{ID: "1", Item: "Widget", Quantity: 3, Version: 1}); err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+
+	err := repo.Update(ctx, order.Order{ID: "1", Item: "Widget", Quantity: 4, Version: 1})
+	if err != order.ErrConflict {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	got, getErr := repo.Get(ctx, "1")
+	if getErr != nil {
+		t.Fatalf("get: %v", getErr)
+	}
+	if got.Quantity != 3 {
+		t.Fatalf("conflicting update must not apply, got quantity %d", got.Quantity)
+	}
+}
+
+func testUpdateMissing(t *testing.T, factory Factory) {
+	repo, cleanup := factory(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if err := repo.Update(ctx, order.Order{ID: "missing"}); err != order.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func testDeleteMissing(t *testing.T, factory Factory) {
+	repo, cleanup := factory(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if err := repo.Delete(ctx, "missing"); err != order.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func testListOrdered(t *testing.T, factory Factory) {
+	repo, cleanup := factory(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	want := []order.Order{
+		{ID: "1", Item: "A", Quantity: 1, Version: 1},
+		{ID: "2", Item: "B", Quantity: 2, Version: 1},
+		{ID: "3", Item: "C", Quantity: 3, Version: 1},
+	}
+	for _, o := range want {
+		if err := repo.Create(ctx, o); err != nil {
+			t.Fatalf("create %s: %v", o.ID, err)
+		}
+	}
+
+	got, err := repo.List(ctx, order.ListOptions{Limit: 50})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(got.Items) != len(want) {
+		t.Fatalf("expected %d orders, got %d", len(want), len(got.Items))
+	}
+	for i := range want {
+		if got.Items[i] != want[i] {
+			t.Fatalf("order %d: got %+v, want %+v", i, got.Items[i], want[i])
+		}
+	}
+	if got.NextCursor != "" {
+		t.Fatalf("expected no next cursor once every item fits on one page, got %q", got.NextCursor)
+	}
+}
+
+func testListPaginates(t *testing.T, factory Factory) {
+	repo, cleanup := factory(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	want := []order.Order{
+		{ID: "1", Item: "A", Quantity: 1, Version: 1},
+		{ID: "2", Item: "B", Quantity: 2, Version: 1},
+		{ID: "3", Item: "C", Quantity: 3, Version: 1},
+	}
+	for _, o := range want {
+		if err := repo.Create(ctx, o); err != nil {
+			t.Fatalf("create %s: %v", o.ID, err)
+		}
+	}
+
+	var got []order.Order
+	cursor := ""
+	for {
+		page, err := repo.List(ctx, order.ListOptions{Limit: 1, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		got = append(got, page.Items...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+		if len(got) > len(want) {
+			t.Fatalf("pagination did not terminate: got %+v", got)
+		}
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d orders across pages, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// testListPaginatesNonDefaultSort checks that paging with a non-id SortBy
+// across more than one page still visits every row exactly once, in sort
+// order. A cursor anchored only on id (rather than on (sortBy, id)) would
+// silently drop rows whose id falls before the previous page's last id but
+// whose sort value belongs later in the page sequence.
+func testListPaginatesNonDefaultSort(t *testing.T, factory Factory) {
+	repo, cleanup := factory(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Quantities are deliberately out of id order: sorted by quantity
+	// ascending this is id2(10), id4(20), id3(30), id5(40), id1(50).
+	seed := []order.Order{
+		{ID: "id1", Item: "A", Quantity: 50, Version: 1},
+		{ID: "id2", Item: "B", Quantity: 10, Version: 1},
+		{ID: "id3", Item: "C", Quantity: 30, Version: 1},
+		{ID: "id4", Item: "D", Quantity: 20, Version: 1},
+		{ID: "id5", Item: "E", Quantity: 40, Version: 1},
+	}
+	for _, o := range seed {
+		if err := repo.Create(ctx, o); err != nil {
+			t.Fatalf("create %s: %v", o.ID, err)
+		}
+	}
+	wantOrder := []string{"id2", "id4", "id3", "id5", "id1"}
+
+	var got []string
+	cursor := ""
+	for {
+		page, err := repo.List(ctx, order.ListOptions{Limit: 2, Cursor: cursor, SortBy: order.SortByQuantity})
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		for _, o := range page.Items {
+			got = append(got, o.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+		if len(got) > len(wantOrder) {
+			t.Fatalf("pagination did not terminate: got %v", got)
+		}
+	}
+
+	if len(got) != len(wantOrder) {
+		t.Fatalf("expected %d orders across pages, got %d: %v", len(wantOrder), len(got), got)
+	}
+	for i := range wantOrder {
+		if got[i] != wantOrder[i] {
+			t.Fatalf("position %d: got id %q, want %q (got order %v)", i, got[i], wantOrder[i], got)
+		}
+	}
+}
+
+// testListStableUnderConcurrentInserts seeds 10k rows, then paginates the
+// full list through keyset cursors while more rows are being inserted
+// concurrently, verifying every seeded row is returned exactly once.
+func testListStableUnderConcurrentInserts(t *testing.T, factory Factory) {
+	if testing.Short() {
+		t.Skip("skipping 10k-row pagination stability test in -short mode")
+	}
+	repo, cleanup := factory(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	const seeded = 10000
+	ids := make(map[string]bool, seeded)
+	for i := 0; i < seeded; i++ {
+		id := fmt.Sprintf("base-%05d", i)
+		if err := repo.Create(ctx, order.Order{ID: id, Item: "seed", Quantity: i}); err != nil {
+			t.Fatalf("seed create %s: %v", id, err)
+		}
+		ids[id] = true
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("concurrent-%03d", i)
+			_ = repo.Create(ctx, order.Order{ID: id, Item: "concurrent", Quantity: i})
+		}(i)
+	}
+	defer wg.Wait()
+
+	seen := make(map[string]int, seeded)
+	cursor := ""
+	for {
+		page, err := repo.List(ctx, order.ListOptions{Limit: 100, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		for _, o := range page.Items {
+			seen[o.ID]++
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	for id := range ids {
+		if seen[id] != 1 {
+			t.Fatalf("order %s seen %d times across pages, want exactly 1", id, seen[id])
+		}
+	}
+}
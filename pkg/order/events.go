@@ -0,0 +1,105 @@
+package order
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// EventType identifies the kind of order lifecycle change an OrderEvent
+// describes.
+type EventType string
+
+// Event types emitted after each successful Repository mutation.
+const (
+	EventCreated EventType = "order.created"
+	EventUpdated EventType = "order.updated"
+	EventDeleted EventType = "order.deleted"
+)
+
+// OrderEvent describes a single order lifecycle change. TraceParent carries
+// the W3C traceparent of the request that caused the change, so consumers
+// can continue the same trace started in traceMiddleware.
+type OrderEvent struct {
+	Type        EventType `json:"type"`
+	Order       Order     `json:"order"`
+	TraceParent string    `json:"traceparent,omitempty"`
+}
+
+// EventPublisher delivers OrderEvents to downstream consumers. Implementations
+// live in pkg/order/events/{memory,kafka,nats}.
+type EventPublisher interface {
+	Publish(ctx context.Context, event OrderEvent) error
+}
+
+// headerCarrier adapts a map to propagation.TextMapCarrier so the current
+// span's traceparent can be written into it without an http.Header.
+type headerCarrier map[string]string
+
+func (c headerCarrier) Get(key string) string { return c[key] }
+func (c headerCarrier) Set(key, value string) { c[key] = value }
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// traceParent extracts the W3C traceparent header for the span active in ctx.
+func traceParent(ctx context.Context) string {
+	c := make(headerCarrier)
+	propagation.TraceContext{}.Inject(ctx, c)
+	return c["traceparent"]
+}
+
+// PublishingRepository decorates a Repository, emitting an OrderEvent through
+// an EventPublisher after each successful mutation. Use this for backends
+// (memory, pop) that have no transactional outbox; the postgres Repository
+// writes its own outbox row instead and is drained by an OutboxRelay.
+type PublishingRepository struct {
+	Repository
+	pub EventPublisher
+}
+
+// NewPublishingRepository wraps repo so Create, Update, and Delete each emit
+// a lifecycle event through pub once the underlying mutation succeeds.
+func NewPublishingRepository(repo Repository, pub EventPublisher) *PublishingRepository {
+	return &PublishingRepository{Repository: repo, pub: pub}
+}
+
+// Create stores o and publishes order.created. Repository.Create only sets
+// fields like Version on its own copy of o, so the stored order is read back
+// via Get before publishing rather than republishing the caller's o as-is.
+func (r *PublishingRepository) Create(ctx context.Context, o Order) error {
+	if err := r.Repository.Create(ctx, o); err != nil {
+		return err
+	}
+	stored, err := r.Repository.Get(ctx, o.ID)
+	if err != nil {
+		return err
+	}
+	return r.pub.Publish(ctx, OrderEvent{Type: EventCreated, Order: stored, TraceParent: traceParent(ctx)})
+}
+
+// Update stores o and publishes order.updated. Repository.Update bumps
+// Version on its own copy of o, so the stored order is read back via Get
+// before publishing rather than republishing the caller's pre-increment o.
+func (r *PublishingRepository) Update(ctx context.Context, o Order) error {
+	if err := r.Repository.Update(ctx, o); err != nil {
+		return err
+	}
+	stored, err := r.Repository.Get(ctx, o.ID)
+	if err != nil {
+		return err
+	}
+	return r.pub.Publish(ctx, OrderEvent{Type: EventUpdated, Order: stored, TraceParent: traceParent(ctx)})
+}
+
+// Delete removes the order identified by id and publishes order.deleted.
+func (r *PublishingRepository) Delete(ctx context.Context, id string) error {
+	if err := r.Repository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.pub.Publish(ctx, OrderEvent{Type: EventDeleted, Order: Order{ID: id}, TraceParent: traceParent(ctx)})
+}
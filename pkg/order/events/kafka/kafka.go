@@ -0,0 +1,53 @@
+// Package kafka implements order.EventPublisher on top of Watermill's Kafka
+// publisher.
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ThreeDotsLabs/watermill"
+	wmkafka "github.com/ThreeDotsLabs/watermill-kafka/v3/pkg/kafka"
+	"github.com/ThreeDotsLabs/watermill/message"
+
+	"orderflow/pkg/order"
+)
+
+// Config configures the Kafka-backed publisher.
+type Config struct {
+	Brokers []string
+	Topic   string
+}
+
+// Publisher emits OrderEvents to a Kafka topic via Watermill.
+type Publisher struct {
+	pub   message.Publisher
+	topic string
+}
+
+// New dials the configured Kafka brokers and returns a Publisher that emits
+// events to cfg.Topic.
+func New(cfg Config) (*Publisher, error) {
+	pub, err := wmkafka.NewPublisher(wmkafka.PublisherConfig{
+		Brokers:   cfg.Brokers,
+		Marshaler: wmkafka.DefaultMarshaler{},
+	}, watermill.NopLogger{})
+	if err != nil {
+		return nil, err
+	}
+	return &Publisher{pub: pub, topic: cfg.Topic}, nil
+}
+
+// Publish encodes event as JSON and publishes it to the configured topic,
+// carrying TraceParent as message metadata so consumers can continue the
+// originating trace.
+func (p *Publisher) Publish(ctx context.Context, event order.OrderEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	msg := message.NewMessage(watermill.NewUUID(), payload)
+	msg.Metadata.Set("traceparent", event.TraceParent)
+	msg.SetContext(ctx)
+	return p.pub.Publish(p.topic, msg)
+}
@@ -0,0 +1,38 @@
+// Package memory implements an in-process order.EventPublisher, useful for
+// tests and local development without a broker.
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"orderflow/pkg/order"
+)
+
+// Publisher records published events in the order they were published.
+type Publisher struct {
+	mu     sync.Mutex
+	events []order.OrderEvent
+}
+
+// New returns an empty Publisher.
+func New() *Publisher {
+	return &Publisher{}
+}
+
+// Publish appends event to the in-memory log.
+func (p *Publisher) Publish(ctx context.Context, event order.OrderEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events returns a copy of every event published so far, in publish order.
+func (p *Publisher) Events() []order.OrderEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]order.OrderEvent, len(p.events))
+	copy(out, p.events)
+	return out
+}
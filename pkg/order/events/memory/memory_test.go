@@ -0,0 +1,21 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"orderflow/pkg/order"
+)
+
+func TestPublisher(t *testing.T) {
+	p := New()
+	ctx := context.Background()
+	evt := order.OrderEvent{Type: order.EventCreated, Order: order.Order{ID: "1", Item: "Widget", Quantity: 2}}
+	if err := p.Publish(ctx, evt); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	got := p.Events()
+	if len(got) != 1 || got[0] != evt {
+		t.Fatalf("expected [%+v], got %+v", evt, got)
+	}
+}
@@ -0,0 +1,53 @@
+// Package nats implements order.EventPublisher on top of Watermill's NATS
+// publisher.
+package nats
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ThreeDotsLabs/watermill"
+	wmnats "github.com/ThreeDotsLabs/watermill-nats/v2/pkg/nats"
+	"github.com/ThreeDotsLabs/watermill/message"
+
+	"orderflow/pkg/order"
+)
+
+// Config configures the NATS-backed publisher.
+type Config struct {
+	URL     string
+	Subject string
+}
+
+// Publisher emits OrderEvents to a NATS subject via Watermill.
+type Publisher struct {
+	pub     message.Publisher
+	subject string
+}
+
+// New dials the configured NATS server and returns a Publisher that emits
+// events to cfg.Subject.
+func New(cfg Config) (*Publisher, error) {
+	pub, err := wmnats.NewPublisher(wmnats.PublisherConfig{
+		URL:       cfg.URL,
+		Marshaler: &wmnats.NATSMarshaler{},
+	}, watermill.NopLogger{})
+	if err != nil {
+		return nil, err
+	}
+	return &Publisher{pub: pub, subject: cfg.Subject}, nil
+}
+
+// Publish encodes event as JSON and publishes it to the configured subject,
+// carrying TraceParent as message metadata so consumers can continue the
+// originating trace.
+func (p *Publisher) Publish(ctx context.Context, event order.OrderEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	msg := message.NewMessage(watermill.NewUUID(), payload)
+	msg.Metadata.Set("traceparent", event.TraceParent)
+	msg.SetContext(ctx)
+	return p.pub.Publish(p.subject, msg)
+}
@@ -0,0 +1,53 @@
+package order_test
+
+import (
+	"context"
+	"testing"
+
+	"orderflow/pkg/order"
+	"orderflow/pkg/order/memory"
+)
+
+type fakePublisher struct {
+	events []order.OrderEvent
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, evt order.OrderEvent) error {
+	p.events = append(p.events, evt)
+	return nil
+}
+
+// TestPublishingRepositoryPublishesStoredVersion checks that the Version on
+// a published event matches what Get returns afterward, not the caller's
+// pre-mutation copy, since Repository.Create/Update only set Version on
+// their own copy of the order.
+func TestPublishingRepositoryPublishesStoredVersion(t *testing.T) {
+	ctx := context.Background()
+	pub := &fakePublisher{}
+	repo := order.NewPublishingRepository(memory.New(), pub)
+
+	o := order.Order{ID: "1", Item: "Widget", Quantity: 2}
+	if err := repo.Create(ctx, o); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	got, err := repo.Get(ctx, o.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(pub.events) != 1 || pub.events[0].Order.Version != got.Version {
+		t.Fatalf("create event version = %+v, want matching %+v", pub.events, got)
+	}
+
+	o = got
+	o.Quantity = 5
+	if err := repo.Update(ctx, o); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got, err = repo.Get(ctx, o.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(pub.events) != 2 || pub.events[1].Order.Version != got.Version {
+		t.Fatalf("update event version = %+v, want matching %+v", pub.events, got)
+	}
+}
@@ -3,6 +3,8 @@ package memory
 
 import (
 	"context"
+	"sort"
+	"strconv"
 	"sync"
 
 	"orderflow/pkg/order"
@@ -19,10 +21,11 @@ func New() *Repository {
 	return &Repository{orders: make(map[string]order.Order)}
 }
 
-// Create stores the order.
+// Create stores the order, starting it at Version 1.
 func (r *Repository) Create(ctx context.Context, o order.Order) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	o.Version = 1
 	r.orders[o.ID] = o
 	return nil
 }
@@ -38,24 +41,86 @@ func (r *Repository) Get(ctx context.Context, id string) (order.Order, error) {
 	return o, nil
 }
 
-// List returns all orders.
-func (r *Repository) List(ctx context.Context) ([]order.Order, error) {
+// List returns a page of orders matching opts, ordered by opts.SortBy with a
+// stable id tiebreak, and paginated with a keyset cursor on (sortBy, id).
+func (r *Repository) List(ctx context.Context, opts order.ListOptions) (order.ListResult, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	out := make([]order.Order, 0, len(r.orders))
+
+	filtered := make([]order.Order, 0, len(r.orders))
 	for _, o := range r.orders {
-		out = append(out, o)
+		if opts.ItemFilter != "" && o.Item != opts.ItemFilter {
+			continue
+		}
+		if opts.MinQuantity != nil && o.Quantity < *opts.MinQuantity {
+			continue
+		}
+		if opts.MaxQuantity != nil && o.Quantity > *opts.MaxQuantity {
+			continue
+		}
+		filtered = append(filtered, o)
+	}
+	sort.Slice(filtered, func(i, j int) bool { return less(filtered[i], filtered[j], opts.SortBy) })
+
+	cursor, err := order.DecodeCursor(opts.Cursor)
+	if err != nil {
+		return order.ListResult{}, err
+	}
+	start := 0
+	if cursor.ID != "" {
+		after := order.Order{ID: cursor.ID, Item: cursor.SortValue}
+		if opts.SortBy == order.SortByQuantity {
+			if after.Quantity, err = strconv.Atoi(cursor.SortValue); err != nil {
+				return order.ListResult{}, err
+			}
+		}
+		start = sort.Search(len(filtered), func(i int) bool { return less(after, filtered[i], opts.SortBy) })
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = order.DefaultListLimit
 	}
-	return out, nil
+	page := filtered[start:]
+	var result order.ListResult
+	if len(page) > limit {
+		result.Items = append([]order.Order(nil), page[:limit]...)
+		result.NextCursor = order.EncodeCursor(opts.SortBy, result.Items[len(result.Items)-1])
+	} else {
+		result.Items = append([]order.Order(nil), page...)
+	}
+	return result, nil
+}
+
+// less orders a before b by sortBy, falling back to id so the order is
+// stable regardless of map iteration order.
+func less(a, b order.Order, sortBy order.SortBy) bool {
+	switch sortBy {
+	case order.SortByItem:
+		if a.Item != b.Item {
+			return a.Item < b.Item
+		}
+	case order.SortByQuantity:
+		if a.Quantity != b.Quantity {
+			return a.Quantity < b.Quantity
+		}
+	}
+	return a.ID < b.ID
 }
 
-// Update replaces an existing order.
+// Update replaces an existing order, requiring o.Version to match the
+// stored version (optimistic concurrency) and bumping it on success.
 func (r *Repository) Update(ctx context.Context, o order.Order) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if _, ok := r.orders[o.ID]; !ok {
+	existing, ok := r.orders[o.ID]
+	if !ok {
 		return order.ErrNotFound
 	}
+	if existing.Version != o.Version {
+		return order.ErrConflict
+	}
+	o.Version = existing.Version + 1
 	r.orders[o.ID] = o
 	return nil
 }
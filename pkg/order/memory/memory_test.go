@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"orderflow/pkg/order"
+	"orderflow/pkg/order/conformance"
 )
 
 func TestRepository(t *testing.T) {
@@ -21,13 +22,13 @@ func TestRepository(t *testing.T) {
 	if got.Item != "Widget" {
 		t.Fatalf("expected Widget, got %s", got.Item)
 	}
-	o.Item = "Gadget"
-	if err := repo.Update(ctx, o); err != nil {
+	got.Item = "Gadget"
+	if err := repo.Update(ctx, got); err != nil {
 		t.Fatalf("update: %v", err)
 	}
-	list, err := repo.List(ctx)
-	if err != nil || len(list) != 1 {
-		t.Fatalf("list: %v len=%d", err, len(list))
+	list, err := repo.List(ctx, order.ListOptions{})
+	if err != nil || len(list.Items) != 1 {
+		t.Fatalf("list: %v len=%d", err, len(list.Items))
 	}
 	if err := repo.Delete(ctx, "1"); err != nil {
 		t.Fatalf("delete: %v", err)
@@ -36,3 +37,12 @@ func TestRepository(t *testing.T) {
 		t.Fatal("expected error after delete")
 	}
 }
+
+// TestConformance runs the shared order.Repository conformance suite so this
+// backend is held to the same ErrNotFound, optimistic-concurrency update,
+// and list ordering semantics as postgres and pop.
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) (order.Repository, func()) {
+		return New(), func() {}
+	})
+}
@@ -2,7 +2,10 @@ package order
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"strconv"
 )
 
 // Order represents a customer purchase order.
@@ -10,16 +13,129 @@ type Order struct {
 	ID       string `json:"id"`
 	Item     string `json:"item"`
 	Quantity int    `json:"quantity"`
+	// Version is incremented on every successful Update and used for
+	// optimistic concurrency: an Update request must carry the Version it
+	// last read, or it fails with ErrConflict.
+	Version int `json:"version"`
+}
+
+// SortBy names an Order field List results can be ordered by.
+type SortBy string
+
+// Supported SortBy values. Pagination is a keyset cursor on (sortBy, id),
+// so every value here pages correctly across multiple pages.
+const (
+	SortByID       SortBy = "id"
+	SortByItem     SortBy = "item"
+	SortByQuantity SortBy = "quantity"
+)
+
+// ListOptions controls pagination, filtering, and sorting for Repository.List.
+type ListOptions struct {
+	// Limit caps the number of items returned; implementations should apply
+	// a sane default when it is <= 0.
+	Limit int
+	// Cursor is the opaque value from a previous ListResult.NextCursor; the
+	// zero value starts from the first page.
+	Cursor string
+	// ItemFilter, if non-empty, restricts results to orders with a matching
+	// Item.
+	ItemFilter string
+	// MinQuantity and MaxQuantity, if non-nil, bound Quantity (inclusive).
+	MinQuantity *int
+	MaxQuantity *int
+	// SortBy orders the page; the zero value sorts by SortByID.
+	SortBy SortBy
+}
+
+// ListResult is a single page of orders plus the cursor for the next page.
+type ListResult struct {
+	Items []Order `json:"items"`
+	// NextCursor is empty once there are no more pages.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Cursor is the decoded keyset position after the last item on a page: the
+// value of the column ListOptions.SortBy ordered on, plus that item's ID as
+// a tiebreak so pagination still terminates when many rows share a sort
+// value. The zero Cursor starts from the first page.
+type Cursor struct {
+	SortValue string
+	ID        string
+}
+
+// cursorPayload is Cursor's wire format.
+type cursorPayload struct {
+	SortValue string `json:"v"`
+	ID        string `json:"id"`
+}
+
+// EncodeCursor opaquely encodes the keyset position after o, the last item
+// on a page sorted by sortBy.
+func EncodeCursor(sortBy SortBy, o Order) string {
+	b, _ := json.Marshal(cursorPayload{SortValue: sortValue(sortBy, o), ID: o.ID})
+	return base64.URLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor reverses EncodeCursor; an empty cursor decodes to the zero
+// Cursor.
+func DecodeCursor(cursor string) (Cursor, error) {
+	if cursor == "" {
+		return Cursor{}, nil
+	}
+	b, err := base64.URLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Cursor{}, err
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return Cursor{}, err
+	}
+	return Cursor{SortValue: payload.SortValue, ID: payload.ID}, nil
+}
+
+// sortValue returns o's value for the column sortBy orders on, as a string
+// suitable for round-tripping through a Cursor.
+func sortValue(sortBy SortBy, o Order) string {
+	switch sortBy {
+	case SortByItem:
+		return o.Item
+	case SortByQuantity:
+		return strconv.Itoa(o.Quantity)
+	default:
+		return o.ID
+	}
+}
+
+// CursorArg converts a Cursor's SortValue back to the Go type comparable
+// against the sortBy column (int for SortByQuantity, string otherwise), for
+// SQL-backed repositories building a composite keyset predicate.
+func CursorArg(sortBy SortBy, sortValue string) (interface{}, error) {
+	if sortBy == SortByQuantity {
+		if sortValue == "" {
+			return 0, nil
+		}
+		return strconv.Atoi(sortValue)
+	}
+	return sortValue, nil
 }
 
 // Repository defines behavior for persisting orders.
 type Repository interface {
 	Create(ctx context.Context, o Order) error
 	Get(ctx context.Context, id string) (Order, error)
-	List(ctx context.Context) ([]Order, error)
+	List(ctx context.Context, opts ListOptions) (ListResult, error)
 	Update(ctx context.Context, o Order) error
 	Delete(ctx context.Context, id string) error
 }
 
 // ErrNotFound indicates the requested order does not exist.
 var ErrNotFound = errors.New("order not found")
+
+// ErrConflict indicates an Update was rejected because the order's Version
+// no longer matches what the caller read, i.e. someone else updated it
+// first.
+var ErrConflict = errors.New("order version conflict")
+
+// DefaultListLimit is applied when ListOptions.Limit is <= 0.
+const DefaultListLimit = 50
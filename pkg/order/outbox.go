@@ -0,0 +1,69 @@
+package order
+
+import (
+	"context"
+	"time"
+)
+
+// OutboxEvent is an undelivered event read back from a Repository's outbox
+// table, keyed by its outbox row ID so it can be acknowledged once published.
+type OutboxEvent struct {
+	ID    int64
+	Event OrderEvent
+}
+
+// OutboxSource is implemented by Repositories (currently only postgres) that
+// persist mutations and their outbox rows in the same transaction, giving
+// at-least-once delivery independent of whether the publisher is reachable.
+type OutboxSource interface {
+	FetchOutbox(ctx context.Context, limit int) ([]OutboxEvent, error)
+	MarkOutboxPublished(ctx context.Context, ids []int64) error
+}
+
+// OutboxRelay polls an OutboxSource and forwards undelivered events to an
+// EventPublisher, acknowledging only the ones that publish successfully so a
+// publisher outage simply delays delivery instead of losing events.
+type OutboxRelay struct {
+	Source    OutboxSource
+	Publisher EventPublisher
+	BatchSize int
+}
+
+// NewOutboxRelay returns a relay with a sensible default batch size.
+func NewOutboxRelay(source OutboxSource, pub EventPublisher) *OutboxRelay {
+	return &OutboxRelay{Source: source, Publisher: pub, BatchSize: 100}
+}
+
+// Run drains the outbox once per interval until ctx is canceled.
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.drain(ctx)
+		}
+	}
+}
+
+// drain publishes a single batch of undelivered events, marking published
+// only those that succeed; the rest are retried on the next tick.
+func (r *OutboxRelay) drain(ctx context.Context) error {
+	events, err := r.Source.FetchOutbox(ctx, r.BatchSize)
+	if err != nil || len(events) == 0 {
+		return err
+	}
+	published := make([]int64, 0, len(events))
+	for _, e := range events {
+		if err := r.Publisher.Publish(ctx, e.Event); err != nil {
+			continue
+		}
+		published = append(published, e.ID)
+	}
+	if len(published) == 0 {
+		return nil
+	}
+	return r.Source.MarkOutboxPublished(ctx, published)
+}
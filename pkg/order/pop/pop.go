@@ -0,0 +1,218 @@
+// Package pop implements order.Repository on top of gobuffalo/pop, so a
+// single DATABASE_URL DSN (postgres://, mysql://, cockroach://, or
+// sqlite3://) selects the backing database without any dialect-specific SQL.
+package pop
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/gobuffalo/fizz"
+	"github.com/gobuffalo/fizz/translators"
+	"github.com/gobuffalo/pop/v6"
+
+	"orderflow/pkg/order"
+)
+
+// ordersSchema describes the orders table as a fizz migration so every
+// dialect pop supports gets the same schema without hand-written SQL.
+// Timestamps are disabled since model has no CreatedAt/UpdatedAt fields for
+// pop to populate; fizz's create_table adds NOT NULL created_at/updated_at
+// columns by default, which would otherwise fail every insert.
+const ordersSchema = `create_table("orders") {
+	t.Column("id", "string", {"primary": true})
+	t.Column("item", "string", {})
+	t.Column("quantity", "int", {})
+	t.Column("version", "int", {"default": 1})
+	t.DisableTimestamps()
+}
+`
+
+// Repository persists orders via gobuffalo/pop.
+type Repository struct {
+	conn *pop.Connection
+}
+
+// New opens the named pop connection, as configured in database.yml (e.g.
+// "production", which resolves its DSN from DATABASE_URL), and migrates the
+// orders table if it does not already exist.
+func New(connectionName string) (*Repository, error) {
+	conn, err := pop.Connect(connectionName)
+	if err != nil {
+		return nil, fmt.Errorf("pop: connect %q: %w", connectionName, err)
+	}
+	if err := migrate(conn); err != nil {
+		return nil, fmt.Errorf("pop: migrate: %w", err)
+	}
+	return &Repository{conn: conn}, nil
+}
+
+// migrate applies ordersSchema using the fizz translator for conn's dialect,
+// unless an orders table already exists.
+func migrate(conn *pop.Connection) error {
+	if err := conn.RawQuery("SELECT 1 FROM orders WHERE 1 = 0").Exec(); err == nil {
+		return nil
+	}
+	var t fizz.Translator
+	switch conn.Dialect.Name() {
+	case "postgres":
+		t = translators.NewPostgres()
+	case "mysql":
+		t = translators.NewMySQL(conn.URL(), conn.Dialect.Details().Database)
+	case "cockroach":
+		t = translators.NewCockroach(conn.URL(), conn.Dialect.Details().Database)
+	case "sqlite3":
+		t = translators.NewSQLite(conn.URL())
+	default:
+		return fmt.Errorf("pop: unsupported dialect %q", conn.Dialect.Name())
+	}
+	ddl, err := fizz.AString(ordersSchema, t)
+	if err != nil {
+		return fmt.Errorf("pop: translate schema: %w", err)
+	}
+	return conn.RawQuery(ddl).Exec()
+}
+
+// model is the pop-mapped row for the orders table.
+type model struct {
+	ID       string `db:"id"`
+	Item     string `db:"item"`
+	Quantity int    `db:"quantity"`
+	Version  int    `db:"version"`
+}
+
+// TableName tells pop to use "orders" instead of the pluralized struct name.
+func (model) TableName() string { return "orders" }
+
+func fromOrder(o order.Order) model {
+	return model{ID: o.ID, Item: o.Item, Quantity: o.Quantity, Version: o.Version}
+}
+
+func (m model) toOrder() order.Order {
+	return order.Order{ID: m.ID, Item: m.Item, Quantity: m.Quantity, Version: m.Version}
+}
+
+// Create inserts a new order, starting it at Version 1.
+func (r *Repository) Create(ctx context.Context, o order.Order) error {
+	m := fromOrder(o)
+	m.Version = 1
+	return r.conn.WithContext(ctx).Create(&m)
+}
+
+// Get retrieves an order by ID.
+func (r *Repository) Get(ctx context.Context, id string) (order.Order, error) {
+	var m model
+	if err := r.conn.WithContext(ctx).Find(&m, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return order.Order{}, order.ErrNotFound
+		}
+		return order.Order{}, err
+	}
+	return m.toOrder(), nil
+}
+
+// sortColumn maps a validated order.SortBy to its orders column, defaulting
+// to id for unknown values.
+func sortColumn(sortBy order.SortBy) string {
+	switch sortBy {
+	case order.SortByItem:
+		return "item"
+	case order.SortByQuantity:
+		return "quantity"
+	default:
+		return "id"
+	}
+}
+
+// List returns a page of orders matching opts. Pagination is a composite
+// keyset cursor on (sortColumn, id), so the page boundary is consistent with
+// the Order clause regardless of which column opts.SortBy names, including
+// when many rows share a sort value.
+func (r *Repository) List(ctx context.Context, opts order.ListOptions) (order.ListResult, error) {
+	cursor, err := order.DecodeCursor(opts.Cursor)
+	if err != nil {
+		return order.ListResult{}, err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = order.DefaultListLimit
+	}
+	sortCol := sortColumn(opts.SortBy)
+
+	q := r.conn.WithContext(ctx).Q()
+	if cursor.ID != "" {
+		cursorArg, err := order.CursorArg(opts.SortBy, cursor.SortValue)
+		if err != nil {
+			return order.ListResult{}, err
+		}
+		q = q.Where(fmt.Sprintf("(%s, id) > (?, ?)", sortCol), cursorArg, cursor.ID)
+	}
+	if opts.ItemFilter != "" {
+		q = q.Where("item = ?", opts.ItemFilter)
+	}
+	if opts.MinQuantity != nil {
+		q = q.Where("quantity >= ?", *opts.MinQuantity)
+	}
+	if opts.MaxQuantity != nil {
+		q = q.Where("quantity <= ?", *opts.MaxQuantity)
+	}
+
+	var models []model
+	if err := q.Order(fmt.Sprintf("%s asc, id asc", sortCol)).Limit(limit + 1).All(&models); err != nil {
+		return order.ListResult{}, err
+	}
+
+	var result order.ListResult
+	if len(models) > limit {
+		models = models[:limit]
+		result.NextCursor = order.EncodeCursor(opts.SortBy, models[len(models)-1].toOrder())
+	}
+	result.Items = make([]order.Order, len(models))
+	for i, m := range models {
+		result.Items[i] = m.toOrder()
+	}
+	return result, nil
+}
+
+// Update replaces an existing order, requiring o.Version to match the
+// stored version (optimistic concurrency) and bumping it on success. It
+// returns order.ErrNotFound if the order does not exist, or
+// order.ErrConflict if it exists but o.Version is stale. The update is a
+// single conditional UPDATE rather than a Find-then-Update, so two
+// concurrent callers racing on the same stale version can't both pass a
+// check and silently clobber one another's write.
+func (r *Repository) Update(ctx context.Context, o order.Order) error {
+	n, err := r.conn.WithContext(ctx).RawQuery(
+		"UPDATE orders SET item = ?, quantity = ?, version = version + 1 WHERE id = ? AND version = ?",
+		o.Item, o.Quantity, o.ID, o.Version,
+	).ExecWithCount()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	exists, err := r.conn.WithContext(ctx).Where("id = ?", o.ID).Exists(&model{})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return order.ErrConflict
+	}
+	return order.ErrNotFound
+}
+
+// Delete removes an order by ID, returning order.ErrNotFound if it does not
+// exist.
+func (r *Repository) Delete(ctx context.Context, id string) error {
+	var m model
+	if err := r.conn.WithContext(ctx).Find(&m, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return order.ErrNotFound
+		}
+		return err
+	}
+	return r.conn.WithContext(ctx).Destroy(&m)
+}
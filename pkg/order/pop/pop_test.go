@@ -0,0 +1,32 @@
+package pop
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gobuffalo/pop/v6"
+
+	"orderflow/pkg/order"
+	"orderflow/pkg/order/conformance"
+)
+
+// TestRepository runs the shared conformance suite against a SQLite
+// connection, which requires no external database and is what CI uses to
+// exercise the pop backend.
+func TestRepository(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) (order.Repository, func()) {
+		dsn := fmt.Sprintf("sqlite3://file:%s?mode=memory&cache=shared", t.Name())
+		cd := &pop.ConnectionDetails{Dialect: "sqlite3", URL: dsn}
+		conn, err := pop.NewConnection(cd)
+		if err != nil {
+			t.Fatalf("new connection: %v", err)
+		}
+		if err := conn.Open(); err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		if err := migrate(conn); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+		return &Repository{conn: conn}, func() { conn.Close() }
+	})
+}
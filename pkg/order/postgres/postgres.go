@@ -3,11 +3,18 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
 
 	"orderflow/pkg/order"
 )
 
-// Repository persists orders in PostgreSQL.
+// Repository persists orders in PostgreSQL. Every mutation also writes a row
+// to the outbox table in the same transaction, so OutboxRelay can deliver
+// order lifecycle events at least once even if the publish itself fails.
 type Repository struct {
 	db *sql.DB
 }
@@ -17,62 +24,210 @@ func New(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// Create inserts a new order.
+// Create inserts a new order, starting it at Version 1.
 func (r *Repository) Create(ctx context.Context, o order.Order) error {
-	_, err := r.db.ExecContext(ctx, "INSERT INTO orders (id,item,quantity) VALUES ($1,$2,$3)", o.ID, o.Item, o.Quantity)
-	return err
+	o.Version = 1
+	return r.writeAndEnqueue(ctx, order.EventCreated, o, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO orders (id,item,quantity,version) VALUES ($1,$2,$3,$4)", o.ID, o.Item, o.Quantity, o.Version)
+		return err
+	})
 }
 
 // Get retrieves an order by ID.
 func (r *Repository) Get(ctx context.Context, id string) (order.Order, error) {
 	var o order.Order
-	err := r.db.QueryRowContext(ctx, "SELECT id,item,quantity FROM orders WHERE id=$1", id).Scan(&o.ID, &o.Item, &o.Quantity)
+	err := r.db.QueryRowContext(ctx, "SELECT id,item,quantity,version FROM orders WHERE id=$1", id).Scan(&o.ID, &o.Item, &o.Quantity, &o.Version)
 	if err == sql.ErrNoRows {
 		return order.Order{}, order.ErrNotFound
 	}
 	return o, err
 }
 
-// List fetches all orders.
-func (r *Repository) List(ctx context.Context) ([]order.Order, error) {
-	rows, err := r.db.QueryContext(ctx, "SELECT id,item,quantity FROM orders")
+// sortColumn maps a validated order.SortBy to its orders column, defaulting
+// to id for unknown values.
+func sortColumn(sortBy order.SortBy) string {
+	switch sortBy {
+	case order.SortByItem:
+		return "item"
+	case order.SortByQuantity:
+		return "quantity"
+	default:
+		return "id"
+	}
+}
+
+// List returns a page of orders matching opts. Pagination is a composite
+// keyset cursor on (sortColumn, id) — WHERE (sortCol, id) > (cursor's
+// sortCol, cursor's id), lexicographically — so the page boundary is
+// consistent with the ORDER BY regardless of which column opts.SortBy names,
+// including when many rows share a sort value.
+func (r *Repository) List(ctx context.Context, opts order.ListOptions) (order.ListResult, error) {
+	cursor, err := order.DecodeCursor(opts.Cursor)
 	if err != nil {
-		return nil, err
+		return order.ListResult{}, err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = order.DefaultListLimit
+	}
+	sortCol := sortColumn(opts.SortBy)
+
+	query := "SELECT id,item,quantity,version FROM orders"
+	var args []interface{}
+	var where []string
+	if cursor.ID != "" {
+		cursorArg, err := order.CursorArg(opts.SortBy, cursor.SortValue)
+		if err != nil {
+			return order.ListResult{}, err
+		}
+		args = append(args, cursorArg, cursor.ID)
+		where = append(where, fmt.Sprintf("(%s, id) > ($%d, $%d)", sortCol, len(args)-1, len(args)))
+	}
+	if opts.ItemFilter != "" {
+		args = append(args, opts.ItemFilter)
+		where = append(where, fmt.Sprintf("item = $%d", len(args)))
+	}
+	if opts.MinQuantity != nil {
+		args = append(args, *opts.MinQuantity)
+		where = append(where, fmt.Sprintf("quantity >= $%d", len(args)))
+	}
+	if opts.MaxQuantity != nil {
+		args = append(args, *opts.MaxQuantity)
+		where = append(where, fmt.Sprintf("quantity <= $%d", len(args)))
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, limit+1)
+	query += fmt.Sprintf(" ORDER BY %s, id LIMIT $%d", sortCol, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return order.ListResult{}, err
 	}
 	defer rows.Close()
+
 	var orders []order.Order
 	for rows.Next() {
 		var o order.Order
-		if err := rows.Scan(&o.ID, &o.Item, &o.Quantity); err != nil {
-			return nil, err
+		if err := rows.Scan(&o.ID, &o.Item, &o.Quantity, &o.Version); err != nil {
+			return order.ListResult{}, err
 		}
 		orders = append(orders, o)
 	}
-	return orders, rows.Err()
+	if err := rows.Err(); err != nil {
+		return order.ListResult{}, err
+	}
+
+	var result order.ListResult
+	if len(orders) > limit {
+		result.Items = orders[:limit]
+		result.NextCursor = order.EncodeCursor(opts.SortBy, result.Items[len(result.Items)-1])
+	} else {
+		result.Items = orders
+	}
+	return result, nil
 }
 
-// Update updates an existing order.
+// Update updates an existing order, requiring o.Version to match the row's
+// current version (optimistic concurrency) and bumping it on success. If no
+// row matches id and version, Update distinguishes a missing order
+// (ErrNotFound) from a stale version (ErrConflict) with a follow-up lookup.
 func (r *Repository) Update(ctx context.Context, o order.Order) error {
-	res, err := r.db.ExecContext(ctx, "UPDATE orders SET item=$2, quantity=$3 WHERE id=$1", o.ID, o.Item, o.Quantity)
-	if err != nil {
-		return err
-	}
-	n, _ := res.RowsAffected()
-	if n == 0 {
+	updated := o
+	updated.Version = o.Version + 1
+	return r.writeAndEnqueue(ctx, order.EventUpdated, updated, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE orders SET item=$2, quantity=$3, version=version+1 WHERE id=$1 AND version=$4", o.ID, o.Item, o.Quantity, o.Version)
+		if err != nil {
+			return err
+		}
+		n, _ := res.RowsAffected()
+		if n > 0 {
+			return nil
+		}
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM orders WHERE id=$1)", o.ID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return order.ErrConflict
+		}
 		return order.ErrNotFound
-	}
-	return nil
+	})
 }
 
 // Delete removes an order by ID.
 func (r *Repository) Delete(ctx context.Context, id string) error {
-	res, err := r.db.ExecContext(ctx, "DELETE FROM orders WHERE id=$1", id)
+	return r.writeAndEnqueue(ctx, order.EventDeleted, order.Order{ID: id}, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM orders WHERE id=$1", id)
+		if err != nil {
+			return err
+		}
+		n, _ := res.RowsAffected()
+		if n == 0 {
+			return order.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// writeAndEnqueue runs mutate and, if it succeeds, inserts an outbox row for
+// evtType/o in the same transaction, so the orders table and the outbox can
+// never diverge.
+func (r *Repository) writeAndEnqueue(ctx context.Context, evtType order.EventType, o order.Order, mutate func(*sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	n, _ := res.RowsAffected()
-	if n == 0 {
-		return order.ErrNotFound
+	defer tx.Rollback()
+
+	if err := mutate(tx); err != nil {
+		return err
+	}
+
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	payload, err := json.Marshal(order.OrderEvent{Type: evtType, Order: o, TraceParent: carrier.Get("traceparent")})
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO outbox (event_type, payload) VALUES ($1,$2)", string(evtType), payload); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FetchOutbox returns up to limit undelivered outbox rows, oldest first.
+func (r *Repository) FetchOutbox(ctx context.Context, limit int) ([]order.OutboxEvent, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, payload FROM outbox WHERE published = false ORDER BY id ASC LIMIT $1", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []order.OutboxEvent
+	for rows.Next() {
+		var id int64
+		var payload []byte
+		if err := rows.Scan(&id, &payload); err != nil {
+			return nil, err
+		}
+		var evt order.OrderEvent
+		if err := json.Unmarshal(payload, &evt); err != nil {
+			return nil, err
+		}
+		events = append(events, order.OutboxEvent{ID: id, Event: evt})
+	}
+	return events, rows.Err()
+}
+
+// MarkOutboxPublished marks the outbox rows in ids as delivered so they are
+// not picked up by a later FetchOutbox.
+func (r *Repository) MarkOutboxPublished(ctx context.Context, ids []int64) error {
+	for _, id := range ids {
+		if _, err := r.db.ExecContext(ctx, "UPDATE outbox SET published = true WHERE id=$1", id); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+
+	"orderflow/pkg/order"
+	"orderflow/pkg/order/conformance"
+)
+
+// TestConformance runs the shared order.Repository conformance suite against
+// a real PostgreSQL instance. It requires TEST_DATABASE_URL and is skipped in
+// CI, which exercises the equivalent suite against SQLite via pkg/order/pop
+// instead.
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set; skipping postgres conformance suite")
+	}
+
+	conformance.Run(t, func(t *testing.T) (order.Repository, func()) {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		if _, err := db.Exec("DROP TABLE IF EXISTS orders"); err != nil {
+			t.Fatalf("drop orders table: %v", err)
+		}
+		if _, err := db.Exec("CREATE TABLE orders (id TEXT PRIMARY KEY, item TEXT, quantity INT, version INT NOT NULL DEFAULT 1)"); err != nil {
+			t.Fatalf("create orders table: %v", err)
+		}
+		if _, err := db.Exec("DROP TABLE IF EXISTS outbox"); err != nil {
+			t.Fatalf("drop outbox table: %v", err)
+		}
+		if _, err := db.Exec("CREATE TABLE outbox (id SERIAL PRIMARY KEY, event_type TEXT, payload TEXT, published BOOLEAN DEFAULT false)"); err != nil {
+			t.Fatalf("create outbox table: %v", err)
+		}
+		return New(db), func() { db.Close() }
+	})
+}
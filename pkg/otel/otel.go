@@ -0,0 +1,90 @@
+// Package otel wires up the OpenTelemetry tracing used across the API:
+// starting the global TracerProvider, propagating the W3C traceparent onto
+// incoming requests, and starting per-operation spans.
+package otel
+
+import (
+	"context"
+
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"orderflow/pkg/logger"
+)
+
+// tracerName identifies this package's own tracer to the SDK; AddSpan uses
+// it, and so does InjectTracing when it is given a nil tracer.
+const tracerName = "orderflow"
+
+// Config configures InitTracing.
+type Config struct {
+	// ServiceName tags every span emitted with this process's identity.
+	ServiceName string
+	// Host is reserved for a future OTLP collector endpoint; no OTLP
+	// exporter is wired up yet, so spans are currently written to stdout
+	// regardless of its value.
+	Host string
+	// Probability is the fraction (0 to 1) of traces sampled.
+	Probability float64
+}
+
+// InitTracing builds a TracerProvider for cfg, registers it as the global
+// provider and W3C trace context propagator, and returns it alongside a
+// shutdown func that flushes and closes it. Failures to build the
+// exporter/resource are logged through log and returned as an error.
+func InitTracing(log *logger.Logger, cfg Config) (*sdktrace.TracerProvider, func(context.Context) error, error) {
+	ctx := context.Background()
+
+	exp, err := stdouttrace.New()
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.TraceIDRatioBased(cfg.Probability)),
+	)
+	otelapi.SetTracerProvider(tp)
+	otelapi.SetTextMapPropagator(propagation.TraceContext{})
+	return tp, tp.Shutdown, nil
+}
+
+// InjectTracing starts a root span for an inbound request using tracer (the
+// global tracer when tracer is nil), so every span AddSpan starts later in
+// the same request nests
+// under it, and returns the context carrying it. The span is ended once ctx
+// itself is done, since traceMiddleware has no later hook to end it
+// explicitly after the handler chain returns.
+func InjectTracing(ctx context.Context, tracer trace.Tracer) context.Context {
+	if tracer == nil {
+		tracer = otelapi.Tracer(tracerName)
+	}
+	ctx, span := tracer.Start(ctx, "http.request")
+	context.AfterFunc(ctx, func() { span.End() })
+	return ctx
+}
+
+// AddSpan starts a child span named name under ctx's active trace.
+func AddSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otelapi.Tracer(tracerName).Start(ctx, name)
+}
+
+// GetTraceID returns the hex trace id of the span active on ctx, or "" if
+// none is active.
+func GetTraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}